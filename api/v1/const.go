@@ -0,0 +1,8 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar_v1
+
+// DefaultEndpoint is the default Rollbar Items API endpoint.
+const DefaultEndpoint = "https://api.rollbar.com/api/1/item/"
@@ -0,0 +1,80 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar_v1
+
+// Data is the data portion of a Rollbar Items API payload.
+type Data struct {
+	Environment string    `json:"environment"`
+	Body        *Body     `json:"body"`
+	Level       string    `json:"level,omitempty"`
+	Timestamp   int64     `json:"timestamp,omitempty"`
+	CodeVersion string    `json:"code_version,omitempty"`
+	Platform    string    `json:"platform,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Framework   string    `json:"framework,omitempty"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Server      *Server   `json:"server,omitempty"`
+	Notifier    *Notifier `json:"notifier,omitempty"`
+	Request     *Request  `json:"request,omitempty"`
+	UUID        string    `json:"uuid,omitempty"`
+	Custom      Custom    `json:"custom,omitempty"`
+	Person      *Person   `json:"person,omitempty"`
+	Context     string    `json:"context,omitempty"`
+}
+
+// Custom holds arbitrary key/value data attached to a report, shown as
+// custom data on Rollbar.
+type Custom map[string]interface{}
+
+// Body is the body of a Data, holding either an exception trace or a
+// plain text message.
+type Body struct {
+	Trace   *Trace   `json:"trace,omitempty"`
+	Message *Message `json:"message,omitempty"`
+}
+
+// Trace is a single exception trace.
+type Trace struct {
+	Frames    []Frame    `json:"frames"`
+	Exception *Exception `json:"exception"`
+}
+
+// Frame is a single stack frame within a Trace.
+type Frame struct {
+	Filename string `json:"filename"`
+	Method   string `json:"method"`
+	Lineno   int    `json:"lineno,omitempty"`
+}
+
+// Exception describes the exception raised.
+type Exception struct {
+	Class   string `json:"class"`
+	Message string `json:"message,omitempty"`
+}
+
+// Message is a plain text body, used when there's no exception trace.
+type Message struct {
+	Body string `json:"body"`
+}
+
+// Server describes the server the error occurred on.
+type Server struct {
+	Host   string `json:"host,omitempty"`
+	Root   string `json:"root,omitempty"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// Notifier identifies the client library that sent the report.
+type Notifier struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Payload is the top-level object sent to the Rollbar Items API.
+type Payload struct {
+	AccessToken string `json:"access_token"`
+	Data        *Data  `json:"data"`
+}
@@ -0,0 +1,16 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar_v1
+
+// Request describes the HTTP request being served when an error
+// occurred.
+type Request struct {
+	URL     string            `json:"url,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	UserIP  string            `json:"user_ip,omitempty"`
+	GET     map[string]string `json:"GET,omitempty"`
+	POST    map[string]string `json:"POST,omitempty"`
+}
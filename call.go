@@ -0,0 +1,120 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// Call represents a single report in progress. It is returned by
+// Debug/Info/Error/Warn/Critical and is not sent to Rollbar until Do is
+// called.
+type Call struct {
+	hc      *httpClient
+	d       *dispatcher
+	payload *api.Payload
+	err     error
+	sync    bool
+}
+
+// Sync forces this report to bypass the async queue, if any, and block
+// the caller in Do until the report has actually been sent.
+func (c Call) Sync() Call {
+	c.sync = true
+	return c
+}
+
+// Custom attaches arbitrary key/value data to the report, shown as
+// custom data on Rollbar.
+func (c Call) Custom(custom map[string]interface{}) Call {
+	c.payload.Data.Custom = custom
+	return c
+}
+
+// Person attaches the identity of the user affected by this report.
+func (c Call) Person(id, username, email string) Call {
+	c.payload.Data.Person = &api.Person{ID: id, Username: username, Email: email}
+	return c
+}
+
+// Request attaches r's metadata to the report, overriding any request
+// that would otherwise be picked up via WithRequestExtractor.
+func (c Call) Request(r *http.Request) Call {
+	c.payload.Data.Request = newAPIRequest(r, c.hc.redactHeaders())
+	return c
+}
+
+// Fingerprint overrides the stack-derived fingerprint Rollbar uses to
+// group occurrences of the same error together.
+func (c Call) Fingerprint(fingerprint string) Call {
+	c.payload.Data.Fingerprint = fingerprint
+	return c
+}
+
+// Title overrides the title shown for this report in the Rollbar UI.
+func (c Call) Title(title string) Call {
+	c.payload.Data.Title = title
+	return c
+}
+
+// UUID overrides the report's client-generated UUID.
+func (c Call) UUID(uuid string) Call {
+	c.payload.Data.UUID = uuid
+	return c
+}
+
+// Context sets the logical path the error occurred in, e.g.
+// "controllers/users#create".
+func (c Call) Context(path string) Call {
+	c.payload.Data.Context = path
+	return c
+}
+
+// Do sends the report to Rollbar. If the client was created with
+// WithAsync and Sync was not called, Do hands the payload to the
+// background dispatcher and returns immediately without waiting for
+// delivery. If WithIgnoreFunc was configured and returns true for the
+// error this Call was built from, Do drops the report without making
+// an HTTP request.
+func (c Call) Do(ctx context.Context) error {
+	if c.hc.ignoreFunc != nil && c.hc.ignoreFunc(c.err) {
+		return nil
+	}
+
+	c.resolveRequest(ctx)
+
+	if c.d == nil || c.sync {
+		return c.hc.deliver(ctx, c.payload)
+	}
+
+	if !c.d.enqueue(item{payload: c.payload, send: c.hc.deliver}) {
+		if c.hc.spoolDir != "" {
+			if serr := c.hc.spool(c.payload); serr == nil {
+				c.hc.metrics.spooled()
+				return nil
+			}
+		}
+		c.hc.metrics.dropped()
+		return errors.New("rollbar: report dropped, queue is full")
+	}
+	return nil
+}
+
+// resolveRequest fills in the report's request from the client's
+// WithRequestExtractor, if one is configured and Call.Request wasn't
+// already called. It runs on the caller's goroutine so it can use ctx
+// before the payload is potentially handed off to the async queue.
+func (c Call) resolveRequest(ctx context.Context) {
+	if c.payload.Data.Request != nil || c.hc.requestExtractor == nil {
+		return
+	}
+	if r := c.hc.requestExtractor(ctx); r != nil {
+		c.payload.Data.Request = newAPIRequest(r, c.hc.redactHeaders())
+	}
+}
@@ -7,12 +7,14 @@ package rollbar
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"runtime"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -28,6 +30,32 @@ type Client interface {
 	Error(error) Call
 	Warn(error) Call
 	Critical(error) Call
+
+	// Wait blocks until every report queued so far has been sent, or
+	// ctx is done, whichever happens first. It is a no-op unless the
+	// client was created with WithAsync.
+	Wait(ctx context.Context) error
+
+	// Close stops accepting new asynchronous reports and waits for the
+	// queue to drain. If ctx is done first, items still queued are
+	// handled according to WithShutdownPolicy, and Close returns ctx's
+	// error only once every worker has finished the sends it still has
+	// (under ShutdownDrain, that is every pending item). It is a no-op
+	// unless the client was created with WithAsync.
+	Close(ctx context.Context) error
+
+	// Wrap runs f, recovering and reporting any panic at CriticalLevel,
+	// then re-panics so the original caller still observes it.
+	Wrap(f func()) error
+
+	// WrapAndWait behaves like Wrap, but blocks until the panic report
+	// has been flushed before re-panicking.
+	WrapAndWait(f func()) error
+
+	// Replay resubmits every payload persisted to the spool directory
+	// configured via WithSpoolDir, removing each one once it is
+	// successfully sent. It is a no-op unless WithSpoolDir was used.
+	Replay(ctx context.Context) error
 }
 
 type client struct {
@@ -36,21 +64,50 @@ type client struct {
 	errorClient    *httpClient
 	warnClient     *httpClient
 	criticalClient *httpClient
+
+	dispatcher *dispatcher
 }
 
 type httpClient struct {
-	token        string
-	client       *http.Client
-	endpoint     string
-	debug        bool
-	logger       Logger
-	environment  string
-	platform     string
-	codeVersion  string
-	serverHost   string
-	serverRoot   string
-	serverBranch string
-	stackskip    int
+	token            string
+	client           *http.Client
+	endpoint         string
+	debug            bool
+	logger           Logger
+	structuredLogger StructuredLogger
+	environment      string
+	platform         string
+	codeVersion      string
+	serverHost       string
+	serverRoot       string
+	serverBranch     string
+	stackskip        int
+
+	asyncWorkers   int
+	asyncQueueSize int
+	overflow       OverflowPolicy
+
+	person               *api.Person
+	requestExtractor     func(ctx context.Context) *http.Request
+	requestRedactHeaders []string
+
+	transform    func(*api.Payload) *api.Payload
+	scrubFields  []string
+	scrubHeaders []string
+	ignoreFunc   func(error) bool
+
+	retryMax      int
+	retryBase     time.Duration
+	retryMaxDelay time.Duration
+
+	spoolDir string
+	// spoolMu is a pointer, not a plain sync.Mutex, so httpClient stays
+	// safe to copy by value - see New, which copies defaultHTTPClient
+	// into every level-specific client.
+	spoolMu *sync.Mutex
+
+	shutdownPolicy ShutdownPolicy
+	metrics        Metrics
 }
 
 var defaultHTTPClient = httpClient{
@@ -59,7 +116,11 @@ var defaultHTTPClient = httpClient{
 	logger:      nilLogger{},
 	environment: "development",
 	platform:    runtime.GOOS,
-	stackskip:   3, // default is 3
+	// 4 frames sit between CreateStack and the code that called
+	// Debug/Info/Error/Warn/Critical: CreateStack's own frame, payloadAt,
+	// call, and the Level method itself. Skipping all of them lands the
+	// top of the reported trace on the caller's code.
+	stackskip: 4,
 }
 
 // Level level of stack trace.
@@ -85,6 +146,7 @@ const (
 func New(token string, options ...Option) Client {
 	cl := defaultHTTPClient
 	cl.token = token
+	cl.spoolMu = new(sync.Mutex)
 	if debug, err := strconv.ParseBool(os.Getenv("ROLLBAR_DEBUG")); err == nil && debug {
 		cl.debug = debug
 	}
@@ -99,22 +161,101 @@ func New(token string, options ...Option) Client {
 		cl.serverHost, _ = os.Hostname()
 	}
 
-	return &client{
+	cli := &client{
 		debugClient:    &cl,
 		infoClient:     &cl,
 		errorClient:    &cl,
 		warnClient:     &cl,
 		criticalClient: &cl,
 	}
+	if cl.asyncWorkers > 0 {
+		cli.dispatcher = newDispatcher(cl.asyncWorkers, cl.asyncQueueSize, cl.overflow, cl.logger)
+	}
+
+	return cli
 }
 
-// payload creates the rollbar payload data.
-func (c *httpClient) payload(level Level, err error) *api.Payload {
+// Debug reports err at DebugLevel.
+func (c *client) Debug(err error) Call { return c.call(c.debugClient, DebugLevel, err) }
+
+// Info reports err at InfoLevel.
+func (c *client) Info(err error) Call { return c.call(c.infoClient, InfoLevel, err) }
+
+// Error reports err at ErrorLevel.
+func (c *client) Error(err error) Call { return c.call(c.errorClient, ErrorLevel, err) }
+
+// Warn reports err at WarnLevel.
+func (c *client) Warn(err error) Call { return c.call(c.warnClient, WarnLevel, err) }
+
+// Critical reports err at CriticalLevel.
+func (c *client) Critical(err error) Call { return c.call(c.criticalClient, CriticalLevel, err) }
+
+// call builds the Call for err, capturing the stack on the caller's
+// goroutine so frame information stays accurate regardless of whether
+// the report is later handed off to the async queue. It calls
+// payloadAt directly, the same way callAt does, so the two stay the
+// same number of frames away from CreateStack.
+func (c *client) call(hc *httpClient, level Level, err error) Call {
+	return Call{hc: hc, d: c.dispatcher, payload: hc.payloadAt(level, err, hc.stackskip), err: err}
+}
+
+// callAt is call, but captures the stack at skip instead of hc.stackskip.
+func (c *client) callAt(hc *httpClient, level Level, err error, skip int) Call {
+	return Call{hc: hc, d: c.dispatcher, payload: hc.payloadAt(level, err, skip), err: err}
+}
+
+// Wait implements Client.
+func (c *client) Wait(ctx context.Context) error {
+	if c.dispatcher == nil {
+		return nil
+	}
+	return c.dispatcher.wait(ctx)
+}
+
+// Close implements Client.
+func (c *client) Close(ctx context.Context) error {
+	if c.dispatcher == nil {
+		return nil
+	}
+	hc := c.criticalClient
+	return c.dispatcher.close(ctx, hc.shutdownPolicy, func(it item) {
+		if hc.shutdownPolicy == ShutdownSpool && hc.spoolDir != "" {
+			if err := hc.spool(it.payload); err == nil {
+				hc.metrics.spooled()
+				return
+			}
+		}
+		hc.metrics.dropped()
+	})
+}
+
+// Replay implements Client.
+func (c *client) Replay(ctx context.Context) error {
+	return c.criticalClient.replay(ctx)
+}
+
+// redactHeaders returns the request headers Call.Request and a
+// WithRequestExtractor-supplied request redact, falling back to
+// DefaultRedactHeaders unless WithRedactHeaders configured otherwise.
+func (c *httpClient) redactHeaders() []string {
+	if len(c.requestRedactHeaders) > 0 {
+		return c.requestRedactHeaders
+	}
+	return DefaultRedactHeaders
+}
+
+// payloadAt builds the rollbar payload data, capturing the stack
+// skipping skip innermost frames. call passes c.stackskip directly;
+// entry points that sit deeper in the call stack than a direct
+// Debug/Info/Error/Warn/Critical call - such as a recover inside Wrap
+// or HTTPHandler - pass c.stackskip plus their own extra frames, so
+// the reported trace starts at the code that actually panicked.
+func (c *httpClient) payloadAt(level Level, err error, skip int) *api.Payload {
 	title := "<nil>"
 	if err != nil {
 		title = err.Error()
 	}
-	stack := CreateStack(c.stackskip)
+	stack := CreateStack(skip)
 
 	data := &api.Data{
 		Environment: c.environment,
@@ -135,6 +276,7 @@ func (c *httpClient) payload(level Level, err error) *api.Payload {
 			Name:    Name,
 			Version: Version,
 		},
+		Person: c.person,
 	}
 
 	return &api.Payload{
@@ -143,12 +285,20 @@ func (c *httpClient) payload(level Level, err error) *api.Payload {
 	}
 }
 
-// newRequest creates new http.Request from payload.
+// newRequest creates new http.Request from payload. Any configured
+// transform runs first, then the scrubber runs last, right before
+// serialization, so a transform can't accidentally re-introduce a
+// secret the caller asked to have scrubbed.
 func (c *httpClient) newRequest(payload *api.Payload) (*http.Request, error) {
 	if c.token == "" {
 		return nil, errors.New("empty token")
 	}
 
+	if c.transform != nil {
+		payload = c.transform(payload)
+	}
+	scrub(payload, c.scrubFields, c.scrubHeaders)
+
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to encode payload")
@@ -168,6 +318,10 @@ func (c *httpClient) newRequest(payload *api.Payload) (*http.Request, error) {
 // Do posts payload to rollbar.
 // The returns rollbar response into res.
 func (c *httpClient) Do(ctx context.Context, req *http.Request, res *api.Response) error {
+	if c.debug {
+		c.logDebug(ctx, "debug", "rollbar request", "endpoint", c.endpoint, "payload_bytes", req.ContentLength)
+	}
+
 	resp, err := ctxhttp.Do(ctx, c.client, req)
 	if err != nil {
 		select {
@@ -183,28 +337,68 @@ func (c *httpClient) Do(ctx context.Context, req *http.Request, res *api.Respons
 		resp.Body.Close()
 	}()
 
+	if c.debug {
+		c.logDebug(ctx, "debug", "rollbar response", "endpoint", c.endpoint, "status", resp.StatusCode)
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return errors.Errorf("received response: %s", resp.Status)
+		return &httpStatusError{
+			status:     resp.StatusCode,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			msg:        fmt.Sprintf("received response: %s", resp.Status),
+		}
 	}
 
 	return c.parseResponse(ctx, resp.Body, res)
 }
 
+// httpStatusError is returned by Do for a non-200 response. It carries
+// the status code and any Retry-After duration so the retry subsystem
+// added by WithRetry can honor Rollbar's rate limiting.
+type httpStatusError struct {
+	status     int
+	retryAfter time.Duration
+	msg        string
+}
+
+func (e *httpStatusError) Error() string { return e.msg }
+
+// parseRetryAfter parses a Retry-After header given in seconds. It
+// returns 0 for anything else, including the HTTP-date form, which
+// Rollbar's API does not send.
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// logDebug emits a debug diagnostic as key/value pairs through the
+// configured StructuredLogger, falling back to Logger.Debugf when none
+// is set.
+func (c *httpClient) logDebug(ctx context.Context, level, msg string, keyvals ...interface{}) {
+	if c.structuredLogger != nil {
+		c.structuredLogger(ctx, level, msg, keyvals...)
+		return
+	}
+	c.logger.Debugf(ctx, "%s %v\n", msg, keyvals)
+}
+
 // parseResponse parses the rollbar API response.
 func (c *httpClient) parseResponse(ctx context.Context, rdr io.Reader, resp *api.Response) error {
 	if c.debug {
 		buf := new(bytes.Buffer)
 		io.Copy(buf, rdr)
 
-		c.logger.Debugf(ctx, "-----> %s (response)\n", c.endpoint)
+		body := buf.String()
 		var m map[string]interface{}
-		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
-			c.logger.Debugf(ctx, "failed to unmarshal payload: %v", err)
-		} else {
-			formatted, _ := json.MarshalIndent(m, "", "  ")
-			c.logger.Debugf(ctx, "%s\n", formatted)
+		if err := json.Unmarshal(buf.Bytes(), &m); err == nil {
+			if formatted, err := json.MarshalIndent(m, "", "  "); err == nil {
+				body = string(formatted)
+			}
 		}
-		c.logger.Debugf(ctx, "<----- %s (response)\n", c.endpoint)
+		c.logDebug(ctx, "debug", "rollbar response body", "endpoint", c.endpoint, "body", body)
 		rdr = buf
 	}
 
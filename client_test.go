@@ -0,0 +1,37 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+// TestDebugStackTopFrameIsCallSite guards against the stack capture
+// regressing back to reporting call()/Debug's own frames instead of the
+// caller's, by asserting the top frame of the captured trace matches
+// the exact line Debug was called from.
+func TestDebugStackTopFrameIsCallSite(t *testing.T) {
+	c := &client{}
+	hc := defaultHTTPClient
+	c.debugClient = &hc
+
+	_, wantFile, wantLine, ok := runtime.Caller(0)
+	call := c.Debug(errors.New("boom")) // must stay on the line right after runtime.Caller(0) above
+	wantLine++
+	if !ok {
+		t.Fatal("runtime.Caller(0) failed")
+	}
+
+	frames := call.payload.Data.Body.Trace.Frames
+	if len(frames) == 0 {
+		t.Fatal("captured stack has no frames")
+	}
+	got := frames[0]
+	if got.Filename != wantFile || got.Lineno != wantLine {
+		t.Fatalf("top frame = %s:%d, want %s:%d (internal library frames leaked into the trace)", got.Filename, got.Lineno, wantFile, wantLine)
+	}
+}
@@ -0,0 +1,17 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+const (
+	// Name is the name of this notifier, reported to the Rollbar API.
+	Name = "go-rollbar"
+	// Version is the version of this notifier, reported to the Rollbar API.
+	Version = "0.1.0"
+	// language is the language of the running notifier.
+	language = "go"
+)
+
+// UserAgent is the User-Agent header sent with every request to the Rollbar API.
+var UserAgent = Name + "/" + Version
@@ -0,0 +1,218 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// errDropped is delivered to an item's done channel when the item was
+// evicted from the queue before it could be sent.
+var errDropped = errors.New("rollbar: report dropped, queue overflowed")
+
+// OverflowPolicy controls what happens when an async queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the caller until space is available in the queue.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued item to make room for the new one.
+	OverflowDropOldest
+	// OverflowDropNewest discards the item being enqueued, keeping the queue as-is.
+	OverflowDropNewest
+)
+
+// item is a single unit of work processed by a dispatcher.
+type item struct {
+	payload *api.Payload
+	send    func(ctx context.Context, payload *api.Payload) error
+	done    chan error
+}
+
+// dispatcher fans queued items out across a fixed pool of worker
+// goroutines, mirroring how the official rollbar-go client ships items
+// in the background instead of blocking the reporting goroutine.
+type dispatcher struct {
+	queue    chan item
+	overflow OverflowPolicy
+	logger   Logger
+
+	workersWG sync.WaitGroup
+	pendingWG sync.WaitGroup
+
+	mu      sync.Mutex
+	dropped int64
+
+	// closeMu is held for reading by enqueue for the duration of a send
+	// attempt, and taken for writing by close once d.closed is closed,
+	// so close can tell when no goroutine is still trying to send on
+	// d.queue before it closes it.
+	closeMu   sync.RWMutex
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newDispatcher(workers, queueSize int, overflow OverflowPolicy, logger Logger) *dispatcher {
+	if logger == nil {
+		logger = nilLogger{}
+	}
+	d := &dispatcher{
+		queue:    make(chan item, queueSize),
+		overflow: overflow,
+		logger:   logger,
+		closed:   make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		d.workersWG.Add(1)
+		go d.work()
+	}
+	return d
+}
+
+func (d *dispatcher) work() {
+	defer d.workersWG.Done()
+	for it := range d.queue {
+		err := it.send(context.Background(), it.payload)
+		if it.done != nil {
+			it.done <- err
+		}
+		d.pendingWG.Done()
+	}
+}
+
+// enqueue submits it according to the dispatcher's overflow policy. It
+// reports false if the item was not queued: either it was dropped for
+// overflow, in which case it has already been logged and counted, or
+// the dispatcher is closed, in which case it is neither logged nor
+// counted.
+func (d *dispatcher) enqueue(it item) bool {
+	d.closeMu.RLock()
+	defer d.closeMu.RUnlock()
+
+	select {
+	case <-d.closed:
+		return false
+	default:
+	}
+
+	switch d.overflow {
+	case OverflowDropNewest:
+		select {
+		case d.queue <- it:
+			d.pendingWG.Add(1)
+			return true
+		default:
+			d.drop()
+			return false
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case d.queue <- it:
+				d.pendingWG.Add(1)
+				return true
+			default:
+				select {
+				case old := <-d.queue:
+					if old.done != nil {
+						old.done <- errDropped
+					}
+					d.pendingWG.Done()
+					d.drop()
+				default:
+				}
+			}
+		}
+	default: // OverflowBlock
+		select {
+		case d.queue <- it:
+			d.pendingWG.Add(1)
+			return true
+		case <-d.closed:
+			return false
+		}
+	}
+}
+
+func (d *dispatcher) drop() {
+	d.mu.Lock()
+	d.dropped++
+	n := d.dropped
+	d.mu.Unlock()
+	d.logger.Debugf(context.Background(), "rollbar: dropped queued item, queue is full (dropped=%d)\n", n)
+}
+
+// Dropped returns the number of items discarded so far due to queue
+// overflow.
+func (d *dispatcher) Dropped() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dropped
+}
+
+// wait blocks until every enqueued item has been sent, or ctx is done,
+// whichever happens first.
+func (d *dispatcher) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.pendingWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting new items and waits for the queue to drain
+// until ctx is done. Worker goroutines keep running past ctx's deadline
+// so already in-flight sends aren't interrupted.
+//
+// If ctx is done before the queue drains and policy is not
+// ShutdownDrain, close hands every item still sitting in the queue
+// (but not yet claimed by a worker) to onRemaining - e.g. to spool it
+// to disk or drop it - instead of leaving it to finish on its own.
+//
+// Once the queue has been drained (or abandoned per policy), close
+// closes d.queue and waits for every worker goroutine to exit before
+// returning, so a caller that observes close return knows no worker
+// goroutines are left running.
+func (d *dispatcher) close(ctx context.Context, policy ShutdownPolicy, onRemaining func(item)) error {
+	var err error
+	d.closeOnce.Do(func() {
+		close(d.closed)
+
+		// Wait for every enqueue already past the d.closed check to
+		// finish its send attempt before closing d.queue, so work()
+		// never sees a send on a closed channel.
+		d.closeMu.Lock()
+		d.closeMu.Unlock()
+
+		err = d.wait(ctx)
+		if err != nil && policy != ShutdownDrain {
+		drain:
+			for {
+				select {
+				case it := <-d.queue:
+					onRemaining(it)
+					d.pendingWG.Done()
+				default:
+					break drain
+				}
+			}
+		}
+
+		close(d.queue)
+		d.workersWG.Wait()
+	})
+	return err
+}
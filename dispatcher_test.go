@@ -0,0 +1,153 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// blockingSend returns a send func that blocks until release is closed,
+// so tests can control exactly when queued items are allowed to drain.
+func blockingSend(release <-chan struct{}) func(context.Context, *api.Payload) error {
+	return func(ctx context.Context, payload *api.Payload) error {
+		<-release
+		return nil
+	}
+}
+
+func countingSend(n *int32, mu *sync.Mutex) func(context.Context, *api.Payload) error {
+	return func(ctx context.Context, payload *api.Payload) error {
+		mu.Lock()
+		*n++
+		mu.Unlock()
+		return nil
+	}
+}
+
+func TestDispatcherOverflowBlock(t *testing.T) {
+	// No workers, so nothing drains the queue on its own and the second
+	// enqueue below is guaranteed to observe it full.
+	d := newDispatcher(0, 1, OverflowBlock, nil)
+	d.enqueue(item{payload: &api.Payload{}})
+
+	done := make(chan bool)
+	go func() {
+		done <- d.enqueue(item{payload: &api.Payload{}})
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("enqueue should have blocked with the queue full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	<-d.queue // free up the slot, as a worker would
+	if ok := <-done; !ok {
+		t.Fatal("blocked enqueue should have succeeded once the queue had space")
+	}
+}
+
+func TestDispatcherOverflowDropNewest(t *testing.T) {
+	d := newDispatcher(0, 1, OverflowDropNewest, nil)
+	if !d.enqueue(item{payload: &api.Payload{}, send: blockingSend(make(chan struct{}))}) {
+		t.Fatal("first enqueue should have filled the queue")
+	}
+	if d.enqueue(item{payload: &api.Payload{}, send: blockingSend(make(chan struct{}))}) {
+		t.Fatal("second enqueue should have been dropped, queue is full")
+	}
+	if got := d.Dropped(); got != 1 {
+		t.Fatalf("Dropped() = %d, want 1", got)
+	}
+}
+
+func TestDispatcherOverflowDropOldest(t *testing.T) {
+	d := newDispatcher(0, 1, OverflowDropOldest, nil)
+	first := item{payload: &api.Payload{}, send: blockingSend(make(chan struct{})), done: make(chan error, 1)}
+	if !d.enqueue(first) {
+		t.Fatal("first enqueue should have filled the queue")
+	}
+
+	second := item{payload: &api.Payload{}, send: blockingSend(make(chan struct{}))}
+	if !d.enqueue(second) {
+		t.Fatal("second enqueue should have evicted the first and succeeded")
+	}
+
+	select {
+	case err := <-first.done:
+		if err != errDropped {
+			t.Fatalf("first.done = %v, want errDropped", err)
+		}
+	default:
+		t.Fatal("evicted item should have been notified on its done channel")
+	}
+	if got := d.Dropped(); got != 1 {
+		t.Fatalf("Dropped() = %d, want 1", got)
+	}
+}
+
+func TestDispatcherCloseWaitsForWorkers(t *testing.T) {
+	var n int32
+	var mu sync.Mutex
+	d := newDispatcher(2, 4, OverflowBlock, nil)
+	for i := 0; i < 4; i++ {
+		d.enqueue(item{payload: &api.Payload{}, send: countingSend(&n, &mu)})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := d.close(ctx, ShutdownDrain, nil); err != nil {
+		t.Fatalf("close() = %v, want nil", err)
+	}
+
+	mu.Lock()
+	got := n
+	mu.Unlock()
+	if got != 4 {
+		t.Fatalf("processed %d items, want 4", got)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.workersWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker goroutines still running after close() returned")
+	}
+}
+
+func TestDispatcherCloseAbandonsRemainingOnDeadline(t *testing.T) {
+	d := newDispatcher(1, 4, OverflowBlock, nil)
+	release := make(chan struct{})
+
+	d.enqueue(item{payload: &api.Payload{}, send: blockingSend(release)})
+	d.enqueue(item{payload: &api.Payload{}, send: blockingSend(release)})
+
+	// Release the item the lone worker is blocked on only after the
+	// deadline below has had a chance to fire, so close() has to
+	// abandon the second, still-queued item rather than draining it.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+
+	var abandoned int
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := d.close(ctx, ShutdownAbandon, func(it item) { abandoned++ })
+	if err == nil {
+		t.Fatal("close() = nil, want a deadline-exceeded error")
+	}
+	if abandoned != 1 {
+		t.Fatalf("abandoned %d items, want 1 (the one still sitting in the queue)", abandoned)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+// errorBody builds the trace portion of a Rollbar payload body from err
+// and the stack captured alongside it.
+func errorBody(err error, stack Stack) *api.Body {
+	msg := "<nil>"
+	if err != nil {
+		msg = err.Error()
+	}
+
+	frames := make([]api.Frame, len(stack))
+	for i, f := range stack {
+		frames[i] = api.Frame{Filename: f.Filename, Method: f.Method, Lineno: f.Lineno}
+	}
+
+	return &api.Body{
+		Trace: &api.Trace{
+			Frames: frames,
+			Exception: &api.Exception{
+				Class:   "error",
+				Message: msg,
+			},
+		},
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gokitlog adapts a go-kit log.Logger into a rollbar.Logger, so
+// Rollbar client diagnostics can be routed into an existing go-kit
+// logging pipeline instead of os.Stderr.
+package gokitlog
+
+import (
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	rollbar "github.com/zchee/go-rollbar"
+	"golang.org/x/net/context"
+)
+
+// logger adapts log.Logger to rollbar.Logger.
+type logger struct {
+	log.Logger
+}
+
+// NewLogger adapts l into a rollbar.Logger suitable for rollbar.WithLogger.
+func NewLogger(l log.Logger) rollbar.Logger {
+	return &logger{Logger: l}
+}
+
+// Debugf implements rollbar.Logger. Debugf's callers follow the
+// fmt.Sprintf-style contract Logger establishes (format plus verbs,
+// interpolated from args), so format is rendered before being logged
+// under the "msg" key rather than logging format and args as separate,
+// unrelated keyvals.
+func (l *logger) Debugf(ctx context.Context, format string, args ...interface{}) {
+	l.Log("msg", fmt.Sprintf(format, args...))
+}
+
+// NewStructuredLogger adapts l into a rollbar.StructuredLogger suitable
+// for rollbar.WithStructuredLogger, logging level and msg as keyvals
+// alongside keyvals unchanged.
+func NewStructuredLogger(l log.Logger) rollbar.StructuredLogger {
+	return func(ctx context.Context, level, msg string, keyvals ...interface{}) {
+		l.Log(append([]interface{}{"level", level, "msg", msg}, keyvals...)...)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// httpPanicStackSkip accounts for the frames HTTPHandler adds above the
+// handler's own code, beyond those stackskip already covers (with
+// reportHTTPPanic in the Level method's place): the deferred recover
+// closure and the runtime's panic frame. It drops the recover frame so
+// reported traces start inside next, at the code that panicked.
+const httpPanicStackSkip = 2
+
+// DefaultRedactHeaders lists the request headers stripped from reports
+// generated by HTTPHandler.
+var DefaultRedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Csrf-Token"}
+
+// HTTPHandler wraps next, recovering any panic raised while it serves a
+// request, reporting it at CriticalLevel with the request attached to
+// the payload, and re-panicking so the server's own recovery (if any)
+// still observes it. Any redact headers given are added to
+// DefaultRedactHeaders for this handler's reports, e.g. to strip a
+// custom API key header in addition to the defaults.
+func HTTPHandler(c Client, next http.Handler, redact ...string) http.Handler {
+	return &httpHandler{c: c, next: next, redact: append(append([]string{}, DefaultRedactHeaders...), redact...)}
+}
+
+// panicReporter is implemented by *client. It lets HTTPHandler attach
+// request metadata and a parameterized stack skip without widening the
+// public Client interface for a detail only HTTPHandler needs.
+type panicReporter interface {
+	reportHTTPPanic(r interface{}, req *http.Request, redact []string) error
+}
+
+type httpHandler struct {
+	c      Client
+	next   http.Handler
+	redact []string
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			if pr, ok := h.c.(panicReporter); ok {
+				pr.reportHTTPPanic(rec, r, h.redact)
+			} else {
+				h.c.Critical(panicError(rec)).Do(context.Background())
+			}
+			panic(rec)
+		}
+	}()
+	h.next.ServeHTTP(w, r)
+}
+
+// reportHTTPPanic reports a panic recovered inside HTTPHandler, attaching
+// req's metadata and a request UUID to the payload.
+func (c *client) reportHTTPPanic(r interface{}, req *http.Request, redact []string) error {
+	call := c.callAt(c.criticalClient, CriticalLevel, panicError(r), c.criticalClient.stackskip+httpPanicStackSkip)
+	call.payload.Data.Request = newAPIRequest(req, redact)
+	call.payload.Data.UUID = newUUID()
+	return call.Do(context.Background())
+}
+
+// newAPIRequest builds an api.Request from r, replacing any header in
+// redact (case-insensitive) with "[FILTERED]".
+func newAPIRequest(r *http.Request, redact []string) *api.Request {
+	filtered := make(map[string]struct{}, len(redact))
+	for _, h := range redact {
+		filtered[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+
+	headers := make(map[string]string, len(r.Header))
+	for k, v := range r.Header {
+		if _, ok := filtered[http.CanonicalHeaderKey(k)]; ok {
+			headers[k] = "[FILTERED]"
+			continue
+		}
+		headers[k] = strings.Join(v, ", ")
+	}
+
+	var get map[string]string
+	if q := r.URL.Query(); len(q) > 0 {
+		get = make(map[string]string, len(q))
+		for k, v := range q {
+			get[k] = strings.Join(v, ", ")
+		}
+	}
+
+	var post map[string]string
+	if len(r.PostForm) > 0 {
+		post = make(map[string]string, len(r.PostForm))
+		for k, v := range r.PostForm {
+			post[k] = strings.Join(v, ", ")
+		}
+	}
+
+	return &api.Request{
+		URL:     r.URL.String(),
+		Method:  r.Method,
+		Headers: headers,
+		UserIP:  remoteIP(r),
+		GET:     get,
+		POST:    post,
+	}
+}
+
+// remoteIP returns the client IP from r.RemoteAddr, stripping the port
+// when present.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
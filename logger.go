@@ -0,0 +1,39 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"fmt"
+	"io"
+
+	"golang.org/x/net/context"
+)
+
+// Logger is the interface used by Client to emit debug information about
+// the requests it sends to Rollbar.
+type Logger interface {
+	Debugf(ctx context.Context, format string, args ...interface{})
+}
+
+// nilLogger discards all debug output. It is the default Logger.
+type nilLogger struct{}
+
+func (nilLogger) Debugf(ctx context.Context, format string, args ...interface{}) {}
+
+// traceLogger writes debug output to w. It backs the logger installed when
+// debug mode is enabled without an explicit Logger option.
+type traceLogger struct {
+	w io.Writer
+}
+
+func (l traceLogger) Debugf(ctx context.Context, format string, args ...interface{}) {
+	fmt.Fprintf(l.w, format, args...)
+}
+
+// StructuredLogger is an alternative to Logger for clients that want
+// Rollbar's debug diagnostics as key/value pairs instead of a formatted
+// string, so they can be routed into an existing logfmt/JSON logging
+// pipeline (see rollbar/gokitlog for a go-kit log.Logger adapter).
+type StructuredLogger func(ctx context.Context, level, msg string, keyvals ...interface{})
@@ -0,0 +1,173 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"net/http"
+	"time"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// Option configures a Client created by New.
+type Option func(*httpClient)
+
+// WithHTTPClient sets the *http.Client used to talk to the Rollbar API.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *httpClient) { c.client = hc }
+}
+
+// WithEndpoint overrides the default Rollbar Items API endpoint.
+func WithEndpoint(endpoint string) Option {
+	return func(c *httpClient) { c.endpoint = endpoint }
+}
+
+// WithLogger sets the Logger used for debug output.
+func WithLogger(logger Logger) Option {
+	return func(c *httpClient) { c.logger = logger }
+}
+
+// WithDebug enables verbose request/response logging.
+func WithDebug(debug bool) Option {
+	return func(c *httpClient) { c.debug = debug }
+}
+
+// WithEnvironment sets the environment reported with every item.
+func WithEnvironment(environment string) Option {
+	return func(c *httpClient) { c.environment = environment }
+}
+
+// WithCodeVersion sets the code version reported with every item.
+func WithCodeVersion(codeVersion string) Option {
+	return func(c *httpClient) { c.codeVersion = codeVersion }
+}
+
+// WithServerRoot sets the path to the application code root.
+func WithServerRoot(root string) Option {
+	return func(c *httpClient) { c.serverRoot = root }
+}
+
+// WithServerBranch sets the checked out VCS branch of the running code.
+func WithServerBranch(branch string) Option {
+	return func(c *httpClient) { c.serverBranch = branch }
+}
+
+// WithStackSkip overrides the number of innermost stack frames skipped
+// when capturing a trace.
+func WithStackSkip(skip int) Option {
+	return func(c *httpClient) { c.stackskip = skip }
+}
+
+// WithAsync enables asynchronous reporting: Call.Do hands payloads to a
+// pool of workers goroutines over a queue of queueSize items instead of
+// blocking the caller on the HTTP round trip. Use Client.Wait or
+// Client.Close to drain the queue before the process exits.
+func WithAsync(workers, queueSize int) Option {
+	return func(c *httpClient) {
+		c.asyncWorkers = workers
+		c.asyncQueueSize = queueSize
+	}
+}
+
+// WithOverflowPolicy sets the policy applied when the async queue is
+// full. It has no effect unless WithAsync is also used.
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(c *httpClient) { c.overflow = policy }
+}
+
+// WithStructuredLogger routes the request/response debug dumps normally
+// sent to Logger.Debugf through sl instead, as key/value pairs. It takes
+// precedence over WithLogger for those diagnostics.
+func WithStructuredLogger(sl StructuredLogger) Option {
+	return func(c *httpClient) { c.structuredLogger = sl }
+}
+
+// WithPerson attaches the given user identity to every report, so
+// callers don't have to repeat Call.Person at every call site.
+func WithPerson(id, username, email string) Option {
+	return func(c *httpClient) { c.person = &api.Person{ID: id, Username: username, Email: email} }
+}
+
+// WithRequestExtractor sets a function that pulls an *http.Request out
+// of a report's context.Context, so every report picks up request
+// metadata automatically - e.g. one bound via middleware - instead of
+// requiring every call site to call Call.Request explicitly. It has no
+// effect on a report that already had Call.Request called on it.
+func WithRequestExtractor(extractor func(ctx context.Context) *http.Request) Option {
+	return func(c *httpClient) { c.requestExtractor = extractor }
+}
+
+// WithRedactHeaders adds header names (case-insensitive) to the set
+// Call.Request and a WithRequestExtractor-supplied request redact as
+// "[FILTERED]", on top of DefaultRedactHeaders.
+func WithRedactHeaders(headers ...string) Option {
+	return func(c *httpClient) {
+		c.requestRedactHeaders = append(append([]string{}, DefaultRedactHeaders...), headers...)
+	}
+}
+
+// WithTransform sets a function that rewrites every payload right
+// before it is sent. It runs before the scrubber configured via
+// WithScrubFields/WithScrubHeaders, so a transform can't accidentally
+// re-introduce a secret the caller asked to have scrubbed.
+func WithTransform(transform func(*api.Payload) *api.Payload) Option {
+	return func(c *httpClient) { c.transform = transform }
+}
+
+// WithScrubFields redacts the given keys (case-insensitive, dotted
+// paths into nested Custom maps supported) from a report's Custom data
+// and request GET/POST parameters, replacing their value with
+// "[FILTERED]", right before it is serialized.
+func WithScrubFields(fields []string) Option {
+	return func(c *httpClient) { c.scrubFields = fields }
+}
+
+// WithScrubHeaders redacts the given request header names
+// (case-insensitive) from a report right before it is serialized.
+func WithScrubHeaders(headers []string) Option {
+	return func(c *httpClient) { c.scrubHeaders = headers }
+}
+
+// WithIgnoreFunc sets a function that decides whether a report should
+// be dropped entirely before the HTTP round trip, given the error it
+// was built from - useful for suppressing context.Canceled or other
+// noisy errors without wrapping every call site.
+func WithIgnoreFunc(ignore func(error) bool) Option {
+	return func(c *httpClient) { c.ignoreFunc = ignore }
+}
+
+// WithRetry enables retrying a failed delivery, making at most
+// maxAttempts attempts in all (the first send included), with
+// full-jitter exponential backoff starting at base and capped at max,
+// honoring any Retry-After duration on a 429/503 response.
+func WithRetry(maxAttempts int, base, max time.Duration) Option {
+	return func(c *httpClient) {
+		c.retryMax = maxAttempts
+		c.retryBase = base
+		c.retryMaxDelay = max
+	}
+}
+
+// WithSpoolDir sets a directory a payload is persisted to as
+// newline-delimited JSON when it can't be delivered - after retries are
+// exhausted, or when Close abandons pending items under
+// ShutdownSpool. Use Client.Replay to resubmit spooled payloads later.
+func WithSpoolDir(path string) Option {
+	return func(c *httpClient) { c.spoolDir = path }
+}
+
+// WithShutdownPolicy sets how Client.Close handles items still pending
+// in the async queue when its context is done before the queue has
+// drained. The default is ShutdownDrain.
+func WithShutdownPolicy(policy ShutdownPolicy) Option {
+	return func(c *httpClient) { c.shutdownPolicy = policy }
+}
+
+// WithMetrics registers callbacks for the retry/spool subsystem, e.g.
+// to wire up Prometheus counters.
+func WithMetrics(metrics Metrics) Option {
+	return func(c *httpClient) { c.metrics = metrics }
+}
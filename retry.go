@@ -0,0 +1,150 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// ShutdownPolicy controls how Client.Close handles items still pending
+// in the async queue when ctx is done before the queue has drained.
+type ShutdownPolicy int
+
+const (
+	// ShutdownDrain keeps waiting for pending items to finish sending
+	// in the background past ctx's deadline. It is the default.
+	ShutdownDrain ShutdownPolicy = iota
+	// ShutdownSpool persists items still queued (but not yet claimed by
+	// a worker) to the spool directory configured via WithSpoolDir.
+	ShutdownSpool
+	// ShutdownAbandon drops items still queued (but not yet claimed by
+	// a worker) without spooling them.
+	ShutdownAbandon
+)
+
+// Metrics lets callers observe the retry/spool subsystem, e.g. to wire
+// up Prometheus counters. Every field is optional.
+type Metrics struct {
+	OnAttempt func()
+	OnRetry   func()
+	OnDropped func()
+	OnSpooled func()
+}
+
+func (m Metrics) attempt() {
+	if m.OnAttempt != nil {
+		m.OnAttempt()
+	}
+}
+
+func (m Metrics) retry() {
+	if m.OnRetry != nil {
+		m.OnRetry()
+	}
+}
+
+func (m Metrics) dropped() {
+	if m.OnDropped != nil {
+		m.OnDropped()
+	}
+}
+
+func (m Metrics) spooled() {
+	if m.OnSpooled != nil {
+		m.OnSpooled()
+	}
+}
+
+// deliver sends payload to Rollbar, retrying with full-jitter backoff
+// if WithRetry was configured, and spooling it to disk on terminal
+// failure if WithSpoolDir was configured.
+func (c *httpClient) deliver(ctx context.Context, payload *api.Payload) error {
+	var err error
+	if c.retryMax > 0 {
+		err = c.sendWithRetry(ctx, payload)
+	} else {
+		err = c.sendOnce(ctx, payload)
+	}
+	if err == nil {
+		return nil
+	}
+
+	if c.spoolDir != "" {
+		if serr := c.spool(payload); serr == nil {
+			c.metrics.spooled()
+			return nil
+		}
+	}
+	return err
+}
+
+// sendOnce performs a single HTTP round trip for payload.
+func (c *httpClient) sendOnce(ctx context.Context, payload *api.Payload) error {
+	c.metrics.attempt()
+	req, err := c.newRequest(payload)
+	if err != nil {
+		return err
+	}
+	var res api.Response
+	return c.Do(ctx, req, &res)
+}
+
+// sendWithRetry calls sendOnce up to c.retryMax times, backing off with
+// full jitter between attempts and honoring any Retry-After duration
+// carried by a 429/503 response.
+func (c *httpClient) sendWithRetry(ctx context.Context, payload *api.Payload) error {
+	var err error
+	for attempt := 0; attempt < c.retryMax; attempt++ {
+		err = c.sendOnce(ctx, payload)
+		if err == nil {
+			return nil
+		}
+		if attempt == c.retryMax-1 {
+			break
+		}
+
+		c.metrics.retry()
+		select {
+		case <-time.After(c.retryDelay(attempt, retryAfter(err))):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// retryDelay returns how long to wait before the next attempt: the
+// Retry-After duration if one was given, otherwise a full-jitter
+// exponential backoff based on c.retryBase, doubled per attempt and
+// capped at c.retryMaxDelay.
+func (c *httpClient) retryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	backoff := c.retryMaxDelay
+	if shifted := c.retryBase << uint(attempt); shifted > 0 && shifted < c.retryMaxDelay {
+		backoff = shifted
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// retryAfter extracts the Retry-After duration from err, if it is an
+// httpStatusError for a 429 or 503 response.
+func retryAfter(err error) time.Duration {
+	se, ok := err.(*httpStatusError)
+	if !ok {
+		return 0
+	}
+	if se.status == http.StatusTooManyRequests || se.status == http.StatusServiceUnavailable {
+		return se.retryAfter
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+func TestRetryDelayHonorsRetryAfter(t *testing.T) {
+	c := &httpClient{retryBase: time.Second, retryMaxDelay: time.Minute}
+	if got := c.retryDelay(0, 30*time.Second); got != 30*time.Second {
+		t.Fatalf("retryDelay() = %v, want the Retry-After duration (30s)", got)
+	}
+}
+
+func TestRetryDelayFullJitterBounds(t *testing.T) {
+	c := &httpClient{retryBase: 100 * time.Millisecond, retryMaxDelay: time.Second}
+	for attempt := 0; attempt < 6; attempt++ {
+		want := c.retryBase << uint(attempt)
+		if want <= 0 || want >= c.retryMaxDelay {
+			want = c.retryMaxDelay
+		}
+		for i := 0; i < 20; i++ {
+			got := c.retryDelay(attempt, 0)
+			if got < 0 || got > want {
+				t.Fatalf("attempt %d: retryDelay() = %v, want within [0, %v]", attempt, got, want)
+			}
+		}
+	}
+}
+
+func TestRetryAfterOnlyAppliesTo429And503(t *testing.T) {
+	permanent := errors.New("boom")
+	cases := []struct {
+		err  error
+		want time.Duration
+	}{
+		{&httpStatusError{status: http.StatusTooManyRequests, retryAfter: 5 * time.Second}, 5 * time.Second},
+		{&httpStatusError{status: http.StatusServiceUnavailable, retryAfter: 2 * time.Second}, 2 * time.Second},
+		{&httpStatusError{status: http.StatusInternalServerError, retryAfter: 5 * time.Second}, 0},
+		{permanent, 0},
+	}
+	for _, tc := range cases {
+		if got := retryAfter(tc.err); got != tc.want {
+			t.Errorf("retryAfter(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+// roundTripFunc adapts a function into an http.RoundTripper, so retry
+// behavior can be exercised without a real HTTP server.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Header:     make(http.Header),
+	}
+}
+
+func TestSendWithRetryStopsOnFirstSuccess(t *testing.T) {
+	var attempts int
+	c := &httpClient{
+		token: "tok", endpoint: "http://rollbar.invalid", retryMax: 3,
+		retryBase: time.Millisecond, retryMaxDelay: time.Millisecond,
+		client: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			attempts++
+			return jsonResponse(http.StatusOK, `{"err":0}`), nil
+		})},
+	}
+
+	if err := c.sendWithRetry(context.Background(), &api.Payload{}); err != nil {
+		t.Fatalf("sendWithRetry() = %v, want nil", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 (no retry after the first success)", attempts)
+	}
+}
+
+func TestSendWithRetryGivesUpAfterRetryMax(t *testing.T) {
+	var attempts int
+	c := &httpClient{
+		token: "tok", endpoint: "http://rollbar.invalid", retryMax: 3,
+		retryBase: time.Millisecond, retryMaxDelay: time.Millisecond,
+		client: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			attempts++
+			return jsonResponse(http.StatusInternalServerError, `{"err":1}`), nil
+		})},
+	}
+
+	if err := c.sendWithRetry(context.Background(), &api.Payload{}); err == nil {
+		t.Fatal("sendWithRetry() = nil, want the last attempt's error")
+	}
+	if attempts != c.retryMax {
+		t.Fatalf("attempts = %d, want retryMax (%d)", attempts, c.retryMax)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rollrus implements a logrus.Hook that forwards log entries to
+// Rollbar, analogous to the rollrus library this package is modeled on.
+package rollrus
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	rollbar "github.com/zchee/go-rollbar"
+	"golang.org/x/net/context"
+)
+
+// Hook is a logrus.Hook that reports entries to Rollbar through a
+// rollbar.Client.
+type Hook struct {
+	client        rollbar.Client
+	triggerLevels []logrus.Level
+}
+
+// NewHookFromClient returns a Hook backed by c, firing for the given
+// logrus levels. If levels is empty, the hook fires for every level
+// logrus defines.
+func NewHookFromClient(c rollbar.Client, levels ...logrus.Level) *Hook {
+	if len(levels) == 0 {
+		levels = logrus.AllLevels
+	}
+	return &Hook{client: c, triggerLevels: levels}
+}
+
+// NewHook builds a rollbar.Client from token and opts, and returns a
+// Hook backed by it.
+func NewHook(token string, opts ...rollbar.Option) *Hook {
+	return NewHookFromClient(rollbar.New(token, opts...))
+}
+
+// MinLevel restricts the hook to firing only for entries at level or
+// more severe, and returns h for chaining.
+func (h *Hook) MinLevel(level logrus.Level) *Hook {
+	var levels []logrus.Level
+	for _, l := range logrus.AllLevels {
+		if l <= level {
+			levels = append(levels, l)
+		}
+	}
+	h.triggerLevels = levels
+	return h
+}
+
+// TriggerLevels returns the logrus levels this hook fires for.
+func (h *Hook) TriggerLevels() []logrus.Level {
+	return h.triggerLevels
+}
+
+// Levels implements logrus.Hook.
+func (h *Hook) Levels() []logrus.Level {
+	return h.triggerLevels
+}
+
+// Fire implements logrus.Hook, reporting entry to Rollbar at the level
+// it maps to and forwarding its fields as custom data.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	call := h.call(entry.Level, extractError(entry))
+
+	if custom := customFields(entry); len(custom) > 0 {
+		call = call.Custom(custom)
+	}
+
+	return call.Do(context.Background())
+}
+
+// call maps level to the matching rollbar.Client method.
+func (h *Hook) call(level logrus.Level, err error) rollbar.Call {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return h.client.Critical(err)
+	case logrus.ErrorLevel:
+		return h.client.Error(err)
+	case logrus.WarnLevel:
+		return h.client.Warn(err)
+	case logrus.InfoLevel:
+		return h.client.Info(err)
+	default:
+		return h.client.Debug(err)
+	}
+}
+
+// extractError pulls an error out of entry, preferring an "error" field
+// and falling back to the entry's message.
+func extractError(entry *logrus.Entry) error {
+	if v, ok := entry.Data["error"]; ok {
+		if err, ok := v.(error); ok {
+			return err
+		}
+		return fmt.Errorf("%v", v)
+	}
+	return fmt.Errorf("%s", entry.Message)
+}
+
+// customFields copies entry.Data into a plain map, dropping the "error"
+// field since it is reported separately.
+func customFields(entry *logrus.Entry) map[string]interface{} {
+	custom := make(map[string]interface{}, len(entry.Data))
+	for k, v := range entry.Data {
+		if k == "error" {
+			continue
+		}
+		custom[k] = v
+	}
+	return custom
+}
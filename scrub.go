@@ -0,0 +1,106 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"strings"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+// filteredValue replaces a scrubbed field's value.
+const filteredValue = "[FILTERED]"
+
+// scrub redacts fields and headers (case-insensitive, dotted paths into
+// nested Custom maps supported) from payload's Custom data, request
+// GET/POST parameters and headers, and server info. It runs after any
+// user transform, as the last step before serialization, so a transform
+// can't accidentally re-introduce a secret the caller asked scrubbed.
+func scrub(payload *api.Payload, fields, headers []string) {
+	if payload == nil || payload.Data == nil {
+		return
+	}
+	data := payload.Data
+
+	if len(fields) > 0 {
+		scrubCustom(data.Custom, fields)
+		scrubServer(data.Server, fields)
+		if data.Request != nil {
+			scrubStrings(data.Request.GET, fields)
+			scrubStrings(data.Request.POST, fields)
+		}
+	}
+	if len(headers) > 0 && data.Request != nil {
+		scrubStrings(data.Request.Headers, headers)
+	}
+}
+
+func scrubCustom(custom api.Custom, fields []string) {
+	if custom == nil {
+		return
+	}
+	for _, field := range fields {
+		scrubPath(custom, strings.Split(field, "."))
+	}
+}
+
+// scrubPath redacts the value at the dotted path described by keys,
+// descending into nested maps for all but the last key.
+func scrubPath(m map[string]interface{}, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	key := matchKey(m, keys[0])
+	if key == "" {
+		return
+	}
+	if len(keys) == 1 {
+		m[key] = filteredValue
+		return
+	}
+	if nested, ok := m[key].(map[string]interface{}); ok {
+		scrubPath(nested, keys[1:])
+	}
+}
+
+// matchKey returns the key in m that equals key case-insensitively, or
+// "" if there is none.
+func matchKey(m map[string]interface{}, key string) string {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+	return ""
+}
+
+func scrubStrings(m map[string]string, fields []string) {
+	if m == nil {
+		return
+	}
+	for _, field := range fields {
+		for k := range m {
+			if strings.EqualFold(k, field) {
+				m[k] = filteredValue
+			}
+		}
+	}
+}
+
+func scrubServer(s *api.Server, fields []string) {
+	if s == nil {
+		return
+	}
+	for _, field := range fields {
+		switch {
+		case strings.EqualFold(field, "host"):
+			s.Host = filteredValue
+		case strings.EqualFold(field, "root"):
+			s.Root = filteredValue
+		case strings.EqualFold(field, "branch"):
+			s.Branch = filteredValue
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"testing"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+func TestScrubPathDottedAndCaseInsensitive(t *testing.T) {
+	custom := api.Custom{
+		"Password": "hunter2",
+		"user": map[string]interface{}{
+			"SSN":   "123-45-6789",
+			"email": "kept@example.com",
+		},
+	}
+
+	scrubCustom(custom, []string{"password", "user.ssn"})
+
+	if custom["Password"] != filteredValue {
+		t.Errorf("custom[Password] = %v, want %v", custom["Password"], filteredValue)
+	}
+	user := custom["user"].(map[string]interface{})
+	if user["SSN"] != filteredValue {
+		t.Errorf("user[SSN] = %v, want %v", user["SSN"], filteredValue)
+	}
+	if user["email"] != "kept@example.com" {
+		t.Errorf("user[email] = %v, want it untouched", user["email"])
+	}
+}
+
+func TestScrubPathMissingKeyIsNoop(t *testing.T) {
+	custom := api.Custom{"password": "hunter2"}
+	scrubCustom(custom, []string{"does.not.exist"})
+	if custom["password"] != "hunter2" {
+		t.Errorf("unrelated key was modified: %v", custom["password"])
+	}
+}
+
+func TestScrubRedactsHeadersRequestAndServer(t *testing.T) {
+	payload := &api.Payload{
+		Data: &api.Data{
+			Custom: api.Custom{"token": "secret"},
+			Server: &api.Server{Host: "web-1", Root: "/app", Branch: "main"},
+			Request: &api.Request{
+				Headers: map[string]string{"Authorization": "Bearer xyz", "Accept": "*/*"},
+				GET:     map[string]string{"api_key": "abc123"},
+			},
+		},
+	}
+
+	scrub(payload, []string{"token", "api_key", "root"}, []string{"authorization"})
+
+	data := payload.Data
+	if data.Custom["token"] != filteredValue {
+		t.Errorf("Custom[token] = %v, want %v", data.Custom["token"], filteredValue)
+	}
+	if data.Request.GET["api_key"] != filteredValue {
+		t.Errorf("GET[api_key] = %v, want %v", data.Request.GET["api_key"], filteredValue)
+	}
+	if data.Request.Headers["Authorization"] != filteredValue {
+		t.Errorf("Headers[Authorization] = %v, want %v", data.Request.Headers["Authorization"], filteredValue)
+	}
+	if data.Request.Headers["Accept"] != "*/*" {
+		t.Errorf("Headers[Accept] = %v, want it untouched", data.Request.Headers["Accept"])
+	}
+	if data.Server.Root != filteredValue {
+		t.Errorf("Server.Root = %v, want %v", data.Server.Root, filteredValue)
+	}
+	if data.Server.Host != "web-1" {
+		t.Errorf("Server.Host = %v, want it untouched", data.Server.Host)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// spoolFilename is the single newline-delimited JSON file a spool
+// directory holds. Every spooled payload is one line.
+const spoolFilename = "spool.ndjson"
+
+// spool appends payload to the configured spool directory so it can be
+// resubmitted later via Client.Replay.
+func (c *httpClient) spool(payload *api.Payload) error {
+	if c.spoolDir == "" {
+		return errors.New("rollbar: no spool directory configured")
+	}
+	if err := os.MkdirAll(c.spoolDir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create spool directory")
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode spooled payload")
+	}
+
+	c.spoolMu.Lock()
+	defer c.spoolMu.Unlock()
+
+	f, err := os.OpenFile(filepath.Join(c.spoolDir, spoolFilename), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open spool file")
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// replay re-reads every payload persisted to the spool directory and
+// resubmits it, rewriting the spool file to hold only the payloads that
+// still fail to send.
+func (c *httpClient) replay(ctx context.Context) error {
+	if c.spoolDir == "" {
+		return nil
+	}
+
+	path := filepath.Join(c.spoolDir, spoolFilename)
+
+	c.spoolMu.Lock()
+	defer c.spoolMu.Unlock()
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read spool file")
+	}
+
+	// Read the whole file up front rather than scanning it line by line,
+	// so a single oversized or truncated line can't stop the read
+	// partway through and silently drop every payload after it.
+	var remaining [][]byte
+	for _, line := range bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		var payload api.Payload
+		if err := json.Unmarshal(line, &payload); err != nil {
+			continue // drop entries we can no longer parse rather than retry them forever
+		}
+		if err := c.sendOnce(ctx, &payload); err != nil {
+			remaining = append(remaining, line)
+		}
+	}
+
+	if len(remaining) == 0 {
+		return os.Remove(path)
+	}
+
+	var buf []byte
+	for _, line := range remaining {
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+	return ioutil.WriteFile(path, buf, 0644)
+}
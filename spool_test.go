@@ -0,0 +1,112 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+func newSpoolClient(t *testing.T, transport roundTripFunc) (*httpClient, string) {
+	dir, err := ioutil.TempDir("", "rollbar-spool-test")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+
+	return &httpClient{
+		token:    "tok",
+		endpoint: "http://rollbar.invalid",
+		spoolDir: dir,
+		spoolMu:  new(sync.Mutex),
+		client:   &http.Client{Transport: transport},
+	}, dir
+}
+
+func TestSpoolReplayRoundTrip(t *testing.T) {
+	var sent []string
+	c, dir := newSpoolClient(t, func(r *http.Request) (*http.Response, error) {
+		sent = append(sent, r.URL.String())
+		return jsonResponse(http.StatusOK, `{"err":0}`), nil
+	})
+	defer os.RemoveAll(dir)
+
+	uuids := []string{"one", "two", "three"}
+	for _, id := range uuids {
+		if err := c.spool(&api.Payload{Data: &api.Data{UUID: id}}); err != nil {
+			t.Fatalf("spool() = %v", err)
+		}
+	}
+
+	if err := c.replay(context.Background()); err != nil {
+		t.Fatalf("replay() = %v", err)
+	}
+	if len(sent) != len(uuids) {
+		t.Fatalf("resubmitted %d payloads, want %d", len(sent), len(uuids))
+	}
+	if _, err := os.Stat(filepath.Join(dir, spoolFilename)); !os.IsNotExist(err) {
+		t.Fatalf("spool file still exists after every payload was replayed successfully: %v", err)
+	}
+}
+
+func TestSpoolReplayKeepsPayloadsThatStillFail(t *testing.T) {
+	c, dir := newSpoolClient(t, func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusInternalServerError, `{"err":1}`), nil
+	})
+	defer os.RemoveAll(dir)
+
+	if err := c.spool(&api.Payload{Data: &api.Data{UUID: "one"}}); err != nil {
+		t.Fatalf("spool() = %v", err)
+	}
+
+	if err := c.replay(context.Background()); err != nil {
+		t.Fatalf("replay() = %v", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, spoolFilename))
+	if err != nil {
+		t.Fatalf("spool file should still exist after a failed replay: %v", err)
+	}
+	if len(data) == 0 {
+		t.Fatal("spool file is empty, want the still-failing payload to have been re-persisted")
+	}
+}
+
+func TestSpoolReplaySkipsUnparseableLinesWithoutLosingOthers(t *testing.T) {
+	var sent int
+	c, dir := newSpoolClient(t, func(r *http.Request) (*http.Response, error) {
+		sent++
+		return jsonResponse(http.StatusOK, `{"err":0}`), nil
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, spoolFilename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll() = %v", err)
+	}
+	corrupt := "not valid json\n"
+	if err := ioutil.WriteFile(path, []byte(corrupt), 0644); err != nil {
+		t.Fatalf("WriteFile() = %v", err)
+	}
+	if err := c.spool(&api.Payload{Data: &api.Data{UUID: "good"}}); err != nil {
+		t.Fatalf("spool() = %v", err)
+	}
+
+	if err := c.replay(context.Background()); err != nil {
+		t.Fatalf("replay() = %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("resubmitted %d payloads, want 1 (the corrupt line should be dropped, not resubmitted or stuck forever)", sent)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("spool file should be removed once the only valid payload replayed successfully: %v", err)
+	}
+}
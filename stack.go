@@ -0,0 +1,50 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"fmt"
+	"hash/fnv"
+	"runtime"
+)
+
+// Frame represents a single stack frame.
+type Frame struct {
+	Filename string
+	Method   string
+	Lineno   int
+}
+
+// Stack is a slice of stack frames, innermost first.
+type Stack []Frame
+
+// CreateStack captures the current goroutine's stack, skipping the
+// innermost skip frames so the reported trace starts at the caller's
+// code rather than inside the rollbar package itself.
+func CreateStack(skip int) Stack {
+	var stack Stack
+	for i := skip; ; i++ {
+		pc, file, line, ok := runtime.Caller(i)
+		if !ok {
+			break
+		}
+		name := "unknown"
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			name = fn.Name()
+		}
+		stack = append(stack, Frame{Filename: file, Method: name, Lineno: line})
+	}
+	return stack
+}
+
+// Fingerprint returns a stable identifier for stack, used by Rollbar to
+// group occurrences of the same error together.
+func (s Stack) Fingerprint() string {
+	h := fnv.New32a()
+	for _, f := range s {
+		fmt.Fprintf(h, "%s:%d", f.Filename, f.Lineno)
+	}
+	return fmt.Sprintf("%x", h.Sum32())
+}
@@ -0,0 +1,67 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// panicStackSkip accounts for the frames Wrap/WrapAndWait add above the
+// caller's own code, beyond those stackskip already covers (with
+// reportPanic in the Level method's place): the deferred recover closure
+// and the runtime's panic frame. It drops the recover frame so reported
+// traces start at f.
+const panicStackSkip = 2
+
+// Wrap runs f, recovering any panic, reporting it at CriticalLevel via
+// the same payloadAt path used by Critical, and then re-panicking so
+// the original caller still observes the panic.
+func (c *client) Wrap(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = c.reportPanic(r)
+			panic(r)
+		}
+	}()
+	f()
+	return nil
+}
+
+// WrapAndWait behaves like Wrap, but blocks until the panic report has
+// been flushed - via the async queue if one is configured - before
+// re-panicking.
+func (c *client) WrapAndWait(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = c.reportPanic(r)
+			c.Wait(context.Background())
+			panic(r)
+		}
+	}()
+	f()
+	return nil
+}
+
+// reportPanic converts r into an error and reports it at CriticalLevel,
+// capturing the stack with the recover frame itself dropped.
+func (c *client) reportPanic(r interface{}) error {
+	call := c.callAt(c.criticalClient, CriticalLevel, panicError(r), c.criticalClient.stackskip+panicStackSkip)
+	return call.Do(context.Background())
+}
+
+// panicError converts a value recovered from panic into an error,
+// preserving the original error or string when possible.
+func panicError(r interface{}) error {
+	switch v := r.(type) {
+	case error:
+		return v
+	case string:
+		return fmt.Errorf("%s", v)
+	default:
+		return fmt.Errorf("%v", v)
+	}
+}